@@ -0,0 +1,174 @@
+package main
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// gitFetchCommand is the base 'git fetch' invocation issued for every
+// repository; -fetch=false appends --dry-run to it so updates are still
+// detected without actually being pulled down.
+var gitFetchCommand = "fetch"
+
+// GitReport is the outcome of analyzing a single repository: its remote,
+// status, fetch, and rev-list output (or the error each produced), plus
+// the branch/remote-tracking-tip metadata the reviewer needs to dedup
+// against the review ledger.
+type GitReport struct {
+	RepoPath string
+
+	RemoteOutput string
+	StatusOutput string
+	FetchOutput  string
+	SkipOutput   string
+	OmitOutput   string
+
+	RevListAhead  string
+	RevListBehind string
+	RevListOutput string
+
+	StatusError  string
+	FetchError   string
+	RevListError string
+
+	Branch     string
+	RemoteHead string
+}
+
+// Analyzer runs the git remote/status/fetch/rev-list pipeline across many
+// repositories concurrently, bounding every subprocess it starts by a
+// per-repository timeout so a single hung git command (an auth prompt, a
+// dead remote) can't stall the whole run.
+type Analyzer struct {
+	workerCount int
+}
+
+// NewAnalyzer builds an Analyzer that processes at most workerCount
+// repositories at a time.
+func NewAnalyzer(workerCount int) *Analyzer {
+	return &Analyzer{workerCount: workerCount}
+}
+
+// AnalyzeAll fans repoPaths out across this Analyzer's worker pool and
+// returns one GitReport per path, in no particular order. Every git
+// subprocess is bound to a context derived from ctx and bounded by
+// timeout, so a single unresponsive repository can't hang the others.
+func (this *Analyzer) AnalyzeAll(ctx context.Context, timeout time.Duration, repoPaths []string, repoConfigs map[string]RepoConfig) []*GitReport {
+	paths := make(chan string)
+	reports := make(chan *GitReport)
+
+	var workers sync.WaitGroup
+	for i := 0; i < this.workerCount; i++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for path := range paths {
+				reports <- this.analyze(ctx, timeout, path, repoConfigs[path])
+			}
+		}()
+	}
+
+	go func() {
+		for _, path := range repoPaths {
+			paths <- path
+		}
+		close(paths)
+	}()
+
+	go func() {
+		workers.Wait()
+		close(reports)
+	}()
+
+	results := make([]*GitReport, 0, len(repoPaths))
+	for report := range reports {
+		results = append(results, report)
+	}
+	return results
+}
+
+// analyze runs the remote/status/fetch/rev-list pipeline for a single
+// repository, comparing against repoConfig's Remote/Branch (review.remote
+// and review.branch, or their auto-detected defaults) rather than a
+// hard-coded origin/master, so repositories that default to "main" or
+// "trunk" are compared correctly.
+func (this *Analyzer) analyze(ctx context.Context, timeout time.Duration, repoPath string, repoConfig RepoConfig) *GitReport {
+	report := &GitReport{RepoPath: repoPath}
+	upstream := repoConfig.Remote + "/" + repoConfig.Branch
+
+	configCtx, cancelConfig := context.WithTimeout(ctx, timeout)
+	defer cancelConfig()
+
+	if skip := gitConfigValue(configCtx, repoPath, "review.skip"); skip == "true" {
+		report.SkipOutput = repoPath
+		return report
+	}
+	if omit := gitConfigValue(configCtx, repoPath, "review.omit"); omit == "true" {
+		report.OmitOutput = repoPath
+	}
+
+	remoteOut, err := this.run(ctx, timeout, repoPath, "remote", "-v")
+	if err != nil {
+		report.StatusError = err.Error()
+	}
+	report.RemoteOutput = remoteOut
+
+	statusOut, err := this.run(ctx, timeout, repoPath, "status", "--porcelain")
+	if err != nil {
+		report.StatusError += err.Error()
+	}
+	report.StatusOutput = statusOut
+
+	branch, err := this.run(ctx, timeout, repoPath, "rev-parse", "--abbrev-ref", "HEAD")
+	if err != nil {
+		report.StatusError += err.Error()
+	}
+	report.Branch = strings.TrimSpace(branch)
+
+	fetchArgs := append([]string{}, strings.Fields(gitFetchCommand)...)
+	fetchOut, err := this.run(ctx, timeout, repoPath, fetchArgs...)
+	if err != nil {
+		report.FetchError = err.Error()
+	}
+	report.FetchOutput = fetchOut
+
+	head, err := this.run(ctx, timeout, repoPath, "rev-parse", upstream)
+	if err == nil {
+		report.RemoteHead = strings.TrimSpace(head)
+	}
+
+	ahead, err := this.run(ctx, timeout, repoPath, "rev-list", upstream+"..HEAD")
+	if err != nil {
+		report.RevListError += err.Error()
+	}
+	report.RevListAhead = ahead
+
+	behind, err := this.run(ctx, timeout, repoPath, "rev-list", "HEAD.."+upstream)
+	if err != nil {
+		report.RevListError += err.Error()
+	}
+	report.RevListBehind = behind
+	report.RevListOutput = behind
+
+	return report
+}
+
+// run executes 'git <args...>' in repoPath, bounding it by timeout so a
+// hung subprocess is cancelled instead of stalling the whole analysis.
+func (this *Analyzer) run(ctx context.Context, timeout time.Duration, repoPath string, args ...string) (string, error) {
+	cmdCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	fullArgs := append([]string{"-C", repoPath}, args...)
+	out, err := exec.CommandContext(cmdCtx, "git", fullArgs...).CombinedOutput()
+	if err != nil {
+		if cmdCtx.Err() != nil {
+			return string(out), cmdCtx.Err()
+		}
+		return string(out), err
+	}
+	return string(out), nil
+}
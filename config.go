@@ -10,6 +10,7 @@ import (
 	"os/user"
 	"path/filepath"
 	"strings"
+	"time"
 )
 
 type Config struct {
@@ -17,6 +18,7 @@ type Config struct {
 	GitRepositoryPaths []string
 	GitRepositoryRoots []string
 	GitGUILauncher     string
+	Editor             string
 	OutputFilePath     string
 	ReviewAhead        bool
 	ReviewBehind       bool
@@ -24,6 +26,12 @@ type Config struct {
 	ReviewFetched      bool
 	ReviewJournal      bool
 	ReviewMessy        bool
+	PushNotes          bool
+	FetchNotes         bool
+	HTTPAddr           string
+	PollInterval       time.Duration
+	Format             string
+	PerRepoTimeout     time.Duration
 }
 
 func ReadConfig() *Config {
@@ -46,6 +54,15 @@ func ReadConfig() *Config {
 			"-->",
 	)
 
+	flag.StringVar(&config.Editor,
+		"editor", "", ""+
+			"The editor to use for the interactive review queue (opened before"+"\n"+
+			"any GUI windows launch). When unset, the 'review.editor' git-config"+"\n"+
+			"value of the first queued repository is used, then $EDITOR, then"+"\n"+
+			"$VISUAL, then 'vi'."+"\n"+
+			"-->",
+	)
+
 	flag.StringVar(&config.OutputFilePath,
 		"outfile", "SMARTY_REVIEW_LOG", ""+
 			"The path or name of the environment variable containing the"+"\n"+
@@ -78,6 +95,52 @@ func ReadConfig() *Config {
 			"-->",
 	)
 
+	flag.BoolVar(&config.FetchNotes,
+		"fetch-notes", false, ""+
+			"Fetch the shared refs/notes/gitreview ref from origin for each"+"\n"+
+			"repository before analyzing, so review state recorded by"+"\n"+
+			"teammates is taken into account."+"\n"+
+			"-->",
+	)
+
+	flag.BoolVar(&config.PushNotes,
+		"push-notes", false, ""+
+			"Push the shared refs/notes/gitreview ref to origin for each"+"\n"+
+			"journaled repository once the review concludes."+"\n"+
+			"-->",
+	)
+
+	flag.StringVar(&config.HTTPAddr,
+		"http", "", ""+
+			"When set, serve a live HTTP dashboard on this address (e.g.\n"+
+			"':8080') instead of exiting after one review pass. Repeats\n"+
+			"GitAnalyzeAll on the -poll interval."+"\n"+
+			"-->",
+	)
+
+	flag.DurationVar(&config.PollInterval,
+		"poll", 5*time.Minute, ""+
+			"How often the -http dashboard re-analyzes repositories."+"\n"+
+			"-->",
+	)
+
+	flag.StringVar(&config.Format,
+		"format", "markdown", ""+
+			"The format of the final code review log entry: 'markdown'\n"+
+			"(the default, appended to -outfile), 'json', or 'sarif'\n"+
+			"(SARIF 2.1.0, for uploading to code-scanning dashboards)."+"\n"+
+			"-->",
+	)
+
+	flag.DurationVar(&config.PerRepoTimeout,
+		"timeout", 60*time.Second, ""+
+			"The maximum time to let any single git command (remote, status,\n"+
+			"fetch, rev-list) run before it's cancelled and the repository is\n"+
+			"recorded as timed out, rather than letting a hung command (e.g.\n"+
+			"an auth prompt or dead remote) stall the whole run."+"\n"+
+			"-->",
+	)
+
 	review := flag.String(
 		"review", "abejm", ""+
 			"Letter code of repository statuses to review; where (a) is ahead,\n"+
@@ -254,6 +317,23 @@ to the repository. The following command will produce this result:
     git config --add review.omit true
 
 
+Per-Repository Overrides:
+
+A repository can customize how it's reviewed with these config variables:
+
+    git config --add review.branch  trunk        # compare against this instead of origin/master
+    git config --add review.remote  upstream      # default is "origin"
+    git config --add review.gui     gitk          # override -gui for just this repository
+    git config --add review.include true          # force-include in the journal even if the
+                                                    # remote doesn't contain "smartystreets"
+    git config --add review.tags    backend,infra # comma-separated labels surfaced as
+                                                    # groupings in the final report
+
+When review.branch is unset, the default branch is auto-detected from
+'git symbolic-ref refs/remotes/<review.remote>/HEAD', which fixes repos
+that default to "main" or "trunk" instead of "master".
+
+
 CLI Flags:
 `
 
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"log"
+	"net/http"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RepoStatus is a point-in-time snapshot of one repository's review state,
+// safe to read concurrently while GitAnalyzeAll refreshes it on the next
+// poll.
+type RepoStatus struct {
+	Path        string    `json:"path"`
+	Ahead       bool      `json:"ahead"`
+	Behind      bool      `json:"behind"`
+	Messy       bool      `json:"messy"`
+	Error       bool      `json:"error"`
+	Fetched     bool      `json:"fetched"`
+	LastFetched time.Time `json:"last_fetched"`
+}
+
+// DashboardServer exposes a GitReviewer's status over HTTP: an HTML table,
+// a JSON feed for scripting, tarballs of individual repositories, and an
+// endpoint to force a re-fetch. A mutex-guarded snapshot keeps serving
+// requests decoupled from the analyzer goroutines that refresh it.
+type DashboardServer struct {
+	reviewer *GitReviewer
+
+	analyzeMu sync.Mutex
+	mu        sync.RWMutex
+	snapshot  map[string]RepoStatus
+}
+
+func NewDashboardServer(reviewer *GitReviewer) *DashboardServer {
+	return &DashboardServer{
+		reviewer: reviewer,
+		snapshot: make(map[string]RepoStatus),
+	}
+}
+
+// Run refreshes the snapshot immediately, then again on every poll tick,
+// and serves the dashboard on addr. It blocks until the listener fails.
+func (this *DashboardServer) Run(addr string, poll time.Duration) error {
+	this.refresh()
+	go func() {
+		ticker := time.NewTicker(poll)
+		defer ticker.Stop()
+		for range ticker.C {
+			this.refresh()
+		}
+	}()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", this.handleIndex)
+	mux.HandleFunc("/status.json", this.handleStatusJSON)
+	mux.HandleFunc("/repo/", this.handleRepoArchive)
+	mux.HandleFunc("/fetch/", this.handleForceFetch)
+
+	log.Println("Serving gitreview dashboard on", addr)
+	return http.ListenAndServe(addr, mux)
+}
+
+// refresh re-analyzes every repository and rebuilds the snapshot. analyzeMu
+// serializes this against itself, since it both resets and then mutates
+// GitReviewer's unprotected maps directly - the poll ticker and POST
+// /fetch/<name> can otherwise call this concurrently and trigger a
+// concurrent map write.
+func (this *DashboardServer) refresh() {
+	this.analyzeMu.Lock()
+	defer this.analyzeMu.Unlock()
+
+	this.reviewer.Reset()
+	this.reviewer.GitAnalyzeAll()
+	now := time.Now()
+
+	this.mu.Lock()
+	defer this.mu.Unlock()
+
+	for _, path := range this.reviewer.repoPaths {
+		_, erred := this.reviewer.erred[path]
+		_, messy := this.reviewer.messy[path]
+		_, ahead := this.reviewer.ahead[path]
+		_, behind := this.reviewer.behind[path]
+		_, fetched := this.reviewer.fetched[path]
+
+		status := RepoStatus{Path: path, Ahead: ahead, Behind: behind, Messy: messy, Error: erred, Fetched: fetched}
+		if fetched {
+			status.LastFetched = now
+		} else if previous, found := this.snapshot[path]; found {
+			status.LastFetched = previous.LastFetched
+		}
+		this.snapshot[path] = status
+	}
+}
+
+func (this *DashboardServer) statusList() []RepoStatus {
+	this.mu.RLock()
+	defer this.mu.RUnlock()
+
+	list := make([]RepoStatus, 0, len(this.snapshot))
+	for _, status := range this.snapshot {
+		list = append(list, status)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Path < list[j].Path })
+	return list
+}
+
+//noinspection GoUnhandledErrorResult
+func (this *DashboardServer) handleIndex(writer http.ResponseWriter, request *http.Request) {
+	writer.Header().Set("Content-Type", "text/html; charset=utf-8")
+	fmt.Fprintln(writer, "<html><body><table border=\"1\">")
+	fmt.Fprintln(writer, "<tr><th>Repository</th><th>Ahead</th><th>Behind</th><th>Messy</th><th>Error</th><th>Fetched</th><th>Last Fetch</th></tr>")
+	for _, status := range this.statusList() {
+		fmt.Fprintf(writer, "<tr><td>%s</td><td>%v</td><td>%v</td><td>%v</td><td>%v</td><td>%v</td><td>%s</td></tr>\n",
+			html.EscapeString(status.Path), status.Ahead, status.Behind, status.Messy, status.Error, status.Fetched, status.LastFetched.Format(time.RFC3339))
+	}
+	fmt.Fprintln(writer, "</table></body></html>")
+}
+
+func (this *DashboardServer) handleStatusJSON(writer http.ResponseWriter, request *http.Request) {
+	writer.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(writer).Encode(this.statusList()); err != nil {
+		log.Println("Could not encode status.json response:", err)
+	}
+}
+
+func (this *DashboardServer) handleRepoArchive(writer http.ResponseWriter, request *http.Request) {
+	name := strings.TrimSuffix(strings.TrimPrefix(request.URL.Path, "/repo/"), ".tar.gz")
+	path := this.findRepoPath(name)
+	if len(path) == 0 {
+		http.NotFound(writer, request)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/gzip")
+	writer.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name+".tar.gz"))
+
+	cmd := exec.CommandContext(this.reviewer.ctx, "git", "-C", path, "archive", "--format=tar.gz", "HEAD")
+	cmd.Stdout = writer
+	if err := cmd.Run(); err != nil {
+		log.Printf("Could not archive repository %s: %s", path, err)
+	}
+}
+
+func (this *DashboardServer) handleForceFetch(writer http.ResponseWriter, request *http.Request) {
+	if request.Method != http.MethodPost {
+		http.Error(writer, "POST required", http.StatusMethodNotAllowed)
+		return
+	}
+
+	name := strings.TrimPrefix(request.URL.Path, "/fetch/")
+	path := this.findRepoPath(name)
+	if len(path) == 0 {
+		http.NotFound(writer, request)
+		return
+	}
+
+	this.refresh()
+
+	this.mu.RLock()
+	status := this.snapshot[path]
+	this.mu.RUnlock()
+
+	writer.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(writer).Encode(status); err != nil {
+		log.Println("Could not encode fetch response:", err)
+	}
+}
+
+func (this *DashboardServer) findRepoPath(name string) string {
+	for _, path := range this.reviewer.repoPaths {
+		if filepath.Base(path) == name {
+			return path
+		}
+	}
+	return ""
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+func main() {
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	config := ReadConfig()
+	reviewer := NewGitReviewer(ctx, config)
+
+	if len(config.HTTPAddr) > 0 {
+		log.Fatal(NewDashboardServer(reviewer).Run(config.HTTPAddr, config.PollInterval))
+	}
+
+	reviewer.SyncNotesBeforeAnalysis()
+	reviewer.GitAnalyzeAll()
+	reviewer.ReviewAll()
+	reviewer.PrintCodeReviewLogEntry()
+	reviewer.SyncNotesAfterReview()
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+	"os/exec"
+	"os/user"
+	"strings"
+	"time"
+)
+
+// notesRef is the dedicated git-notes ref used to record review state,
+// kept separate from the notes humans attach to commits by hand.
+const notesRef = "refs/notes/gitreview"
+
+// ReviewLedger persists, per repository and branch, the last commit SHA
+// that was actually reviewed. It is backed by git-notes rather than a
+// local file so the ledger travels with the repository and can be shared
+// across a team via -push-notes/-fetch-notes.
+type ReviewLedger struct {
+	reviewer string
+}
+
+// ledgerEntry is the JSON payload stored in a refs/notes/gitreview note.
+type ledgerEntry struct {
+	SHA        string `json:"sha"`
+	Branch     string `json:"branch"`
+	Reviewer   string `json:"reviewer"`
+	ReviewedAt string `json:"reviewed_at"`
+}
+
+// NewReviewLedger looks up the reviewer identity (git's user.email, falling
+// back to the OS user) bounded by timeout, so a misconfigured git can't
+// hang construction indefinitely.
+func NewReviewLedger(ctx context.Context, timeout time.Duration) *ReviewLedger {
+	lookupCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	return &ReviewLedger{reviewer: currentReviewer(lookupCtx)}
+}
+
+// LastReviewed returns the SHA recorded against head for repoPath, or ""
+// if head has no note yet, the note can't be parsed, or the notes ref
+// doesn't exist in that repository.
+func (this *ReviewLedger) LastReviewed(ctx context.Context, repoPath, head string) string {
+	if len(head) == 0 {
+		return ""
+	}
+
+	out, err := exec.CommandContext(ctx, "git", "-C", repoPath, "notes", "--ref="+notesRef, "show", head).Output()
+	if err != nil {
+		return ""
+	}
+
+	var entry ledgerEntry
+	if err = json.Unmarshal(bytes.TrimSpace(out), &entry); err != nil {
+		return ""
+	}
+	return entry.SHA
+}
+
+// Record writes a note on head marking it as reviewed just now, replacing
+// any note the ledger already holds for head.
+//
+//noinspection GoUnhandledErrorResult
+func (this *ReviewLedger) Record(ctx context.Context, repoPath, head, branch string) {
+	entry := ledgerEntry{
+		SHA:        head,
+		Branch:     branch,
+		Reviewer:   this.reviewer,
+		ReviewedAt: time.Now().Format(time.RFC3339),
+	}
+
+	payload, err := json.Marshal(entry)
+	if err != nil {
+		log.Println("Could not marshal review ledger entry:", err)
+		return
+	}
+
+	cmd := exec.CommandContext(ctx, "git", "-C", repoPath, "notes", "--ref="+notesRef, "add", "-f", "-m", string(payload), head)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		log.Printf("Could not record review ledger note for %s: %s: %s", repoPath, err, strings.TrimSpace(string(out)))
+	}
+}
+
+// Push shares this repository's review ledger notes with origin.
+func (this *ReviewLedger) Push(ctx context.Context, repoPath string) {
+	this.sync(ctx, repoPath, "push")
+}
+
+// Fetch pulls this repository's review ledger notes from origin.
+func (this *ReviewLedger) Fetch(ctx context.Context, repoPath string) {
+	this.sync(ctx, repoPath, "fetch")
+}
+
+func (this *ReviewLedger) sync(ctx context.Context, repoPath, direction string) {
+	refspec := notesRef + ":" + notesRef
+	cmd := exec.CommandContext(ctx, "git", "-C", repoPath, direction, "origin", refspec)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		log.Printf("Could not %s review ledger notes for %s: %s: %s", direction, repoPath, err, strings.TrimSpace(string(out)))
+	}
+}
+
+func currentReviewer(ctx context.Context) string {
+	if out, err := exec.CommandContext(ctx, "git", "config", "--get", "user.email").Output(); err == nil {
+		if reviewer := strings.TrimSpace(string(out)); len(reviewer) > 0 {
+			return reviewer
+		}
+	}
+	if usr, err := user.Current(); err == nil {
+		return usr.Username
+	}
+	return "unknown"
+}
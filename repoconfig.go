@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+)
+
+// RepoConfig holds the per-repository review.* git-config overrides
+// documented in the README (review.skip, review.omit) extended with
+// review.branch, review.remote, review.gui, review.include, and
+// review.tags.
+type RepoConfig struct {
+	Remote  string
+	Branch  string
+	GUI     string
+	Include bool
+	Tags    []string
+}
+
+// loadRepoConfig reads the review.* git-config keys for repoPath,
+// defaulting Remote to "origin" and auto-detecting Branch from the
+// remote's HEAD when review.branch isn't set, so repositories that
+// default to "main" or "trunk" aren't misreported as behind origin/master.
+func loadRepoConfig(ctx context.Context, repoPath string) RepoConfig {
+	var config RepoConfig
+
+	config.Remote = gitConfigValue(ctx, repoPath, "review.remote")
+	if len(config.Remote) == 0 {
+		config.Remote = "origin"
+	}
+
+	config.Branch = gitConfigValue(ctx, repoPath, "review.branch")
+	if len(config.Branch) == 0 {
+		config.Branch = defaultBranch(ctx, repoPath, config.Remote)
+	}
+
+	config.GUI = gitConfigValue(ctx, repoPath, "review.gui")
+	config.Include = gitConfigValue(ctx, repoPath, "review.include") == "true"
+
+	if tags := gitConfigValue(ctx, repoPath, "review.tags"); len(tags) > 0 {
+		for _, tag := range strings.Split(tags, ",") {
+			tag = strings.TrimSpace(tag)
+			if len(tag) > 0 {
+				config.Tags = append(config.Tags, tag)
+			}
+		}
+	}
+
+	return config
+}
+
+// defaultBranch auto-detects a repository's default branch via
+// 'git symbolic-ref refs/remotes/<remote>/HEAD', falling back to
+// "master" when that symbolic ref was never set up locally. The result is
+// a bare branch name (like review.branch); callers join it to the remote.
+func defaultBranch(ctx context.Context, repoPath, remote string) string {
+	out, err := exec.CommandContext(ctx, "git", "-C", repoPath, "symbolic-ref", "refs/remotes/"+remote+"/HEAD").Output()
+	if err != nil {
+		return "master"
+	}
+
+	ref := strings.TrimSpace(string(out))
+	if idx := strings.LastIndex(ref, "/"); idx >= 0 {
+		return ref[idx+1:]
+	}
+	return "master"
+}
+
+func gitConfigValue(ctx context.Context, repoPath, key string) string {
+	out, err := exec.CommandContext(ctx, "git", "-C", repoPath, "config", "--get", key).Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
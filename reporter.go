@@ -0,0 +1,199 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// ReportEntry captures everything known about one repository's review for
+// the final report, independent of output format.
+type ReportEntry struct {
+	Path           string    `json:"path"`
+	Remote         string    `json:"remote"`
+	Branch         string    `json:"branch"`
+	Ahead          int       `json:"ahead"`
+	Behind         int       `json:"behind"`
+	MessyFiles     []string  `json:"messy_files"`
+	FetchedCommits []string  `json:"fetched_commits"`
+	Errors         []string  `json:"errors"`
+	Tags           []string  `json:"tags"`
+	ReviewedAt     time.Time `json:"reviewed_at"`
+}
+
+// Reporter renders a review run's journaled entries to writer in a
+// particular format. Implementations back the -format flag.
+type Reporter interface {
+	Report(writer io.Writer, entries []ReportEntry) error
+}
+
+// NewReporter returns the Reporter for the named format, falling back to
+// markdown (the historical format) for an empty or unrecognized value.
+func NewReporter(format string) Reporter {
+	switch format {
+	case "json":
+		return jsonReporter{}
+	case "sarif":
+		return sarifReporter{}
+	default:
+		return markdownReporter{}
+	}
+}
+
+// markdownReporter writes the code review log entry as markdown: a dated
+// heading followed by each journaled repository's path and fetched commits,
+// with tagged repositories listed under a heading per review.tags label.
+type markdownReporter struct{}
+
+func (markdownReporter) Report(writer io.Writer, entries []ReportEntry) error {
+	fmt.Fprintln(writer)
+	fmt.Fprintln(writer)
+	fmt.Fprintln(writer, "##", time.Now().Format("2006-01-02"))
+	fmt.Fprintln(writer)
+
+	for _, group := range groupByTag(entries) {
+		if len(group.tag) > 0 {
+			fmt.Fprintln(writer, "###", group.tag)
+			fmt.Fprintln(writer)
+		}
+		for _, entry := range group.entries {
+			fmt.Fprintln(writer, entry.Path)
+			for _, commit := range entry.FetchedCommits {
+				fmt.Fprintln(writer, commit)
+			}
+			fmt.Fprintln(writer)
+		}
+	}
+	return nil
+}
+
+// taggedGroup is one review.tags grouping of entries; untagged entries are
+// grouped under an empty tag, printed first with no heading.
+type taggedGroup struct {
+	tag     string
+	entries []ReportEntry
+}
+
+func groupByTag(entries []ReportEntry) []taggedGroup {
+	var order []string
+	groups := make(map[string][]ReportEntry)
+
+	for _, entry := range entries {
+		tags := entry.Tags
+		if len(tags) == 0 {
+			tags = []string{""}
+		}
+		for _, tag := range tags {
+			if _, found := groups[tag]; !found {
+				order = append(order, tag)
+			}
+			groups[tag] = append(groups[tag], entry)
+		}
+	}
+
+	result := make([]taggedGroup, 0, len(order))
+	if untagged, found := groups[""]; found {
+		result = append(result, taggedGroup{tag: "", entries: untagged})
+	}
+	for _, tag := range order {
+		if len(tag) == 0 {
+			continue
+		}
+		result = append(result, taggedGroup{tag: tag, entries: groups[tag]})
+	}
+	return result
+}
+
+type jsonReporter struct{}
+
+func (jsonReporter) Report(writer io.Writer, entries []ReportEntry) error {
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(entries)
+}
+
+// sarifReporter emits one SARIF result per git error and per uncommitted
+// file of a repository, plus one for each repository behind its upstream,
+// so the report can be uploaded to code-scanning dashboards.
+type sarifReporter struct{}
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool    `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name string `json:"name"`
+}
+
+type sarifResult struct {
+	RuleID    string           `json:"ruleId"`
+	Message   sarifMessage     `json:"message"`
+	Locations []sarifLocation  `json:"locations"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+func (sarifReporter) Report(writer io.Writer, entries []ReportEntry) error {
+	doc := sarifLog{
+		Schema:  "https://raw.githubusercontent.com/oasis-tcs/sarif-spec/master/Schemata/sarif-schema-2.1.0.json",
+		Version: "2.1.0",
+		Runs: []sarifRun{{
+			Tool: sarifTool{Driver: sarifDriver{Name: "gitreview"}},
+		}},
+	}
+
+	for _, entry := range entries {
+		for _, message := range entry.Errors {
+			doc.Runs[0].Results = append(doc.Runs[0].Results, sarifResultFor("git-error", message, entry.Path))
+		}
+		for _, file := range entry.MessyFiles {
+			doc.Runs[0].Results = append(doc.Runs[0].Results, sarifResultFor("uncommitted-changes", file, entry.Path))
+		}
+		if entry.Behind > 0 {
+			message := fmt.Sprintf("%s (on branch %s) is %d commit(s) behind its upstream", entry.Path, entry.Branch, entry.Behind)
+			doc.Runs[0].Results = append(doc.Runs[0].Results, sarifResultFor("behind-master", message, entry.Path))
+		}
+	}
+
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(doc)
+}
+
+func sarifResultFor(ruleID, message, path string) sarifResult {
+	return sarifResult{
+		RuleID:  ruleID,
+		Message: sarifMessage{Text: message},
+		Locations: []sarifLocation{{
+			PhysicalLocation: sarifPhysicalLocation{
+				ArtifactLocation: sarifArtifactLocation{URI: path},
+			},
+		}},
+	}
+}
@@ -1,73 +1,158 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os"
 	"os/exec"
+	"sort"
 	"strings"
 	"time"
 )
 
 type GitReviewer struct {
+	ctx       context.Context
 	config    *Config
 	repoPaths []string
+	ledger    *ReviewLedger
 
-	erred   map[string]string
-	messy   map[string]string
-	ahead   map[string]string
-	behind  map[string]string
-	fetched map[string]string
-	journal map[string]string
-	omitted map[string]string
-	skipped map[string]string
+	erred       map[string]string
+	messy       map[string]string
+	ahead       map[string]string
+	behind      map[string]string
+	fetched     map[string]string
+	journal     map[string]string
+	omitted     map[string]string
+	skipped     map[string]string
+	timedOut    map[string]string
+	branches    map[string]string
+	remotes     map[string]string
+	remoteHeads map[string]string
+
+	repoConfigs map[string]RepoConfig
 }
 
-func NewGitReviewer(config *Config) *GitReviewer {
+// NewGitReviewer builds a GitReviewer whose git subprocesses are bound to
+// ctx, so cancelling ctx (e.g. on SIGINT/SIGTERM) stops in-flight work.
+func NewGitReviewer(ctx context.Context, config *Config) *GitReviewer {
 	return &GitReviewer{
+		ctx:    ctx,
 		config: config,
 		repoPaths: append(
 			collectGitRepositories(config.GitRepositoryRoots),
 			filterGitRepositories(config.GitRepositoryPaths)...,
 		),
-		erred:   make(map[string]string),
-		messy:   make(map[string]string),
-		ahead:   make(map[string]string),
-		behind:  make(map[string]string),
-		fetched: make(map[string]string),
-		journal: make(map[string]string),
-		omitted: make(map[string]string),
-		skipped: make(map[string]string),
+		ledger:      NewReviewLedger(ctx, config.PerRepoTimeout),
+		erred:       make(map[string]string),
+		messy:       make(map[string]string),
+		ahead:       make(map[string]string),
+		behind:      make(map[string]string),
+		fetched:     make(map[string]string),
+		journal:     make(map[string]string),
+		omitted:     make(map[string]string),
+		skipped:     make(map[string]string),
+		timedOut:    make(map[string]string),
+		branches:    make(map[string]string),
+		remotes:     make(map[string]string),
+		remoteHeads: make(map[string]string),
+
+		repoConfigs: make(map[string]RepoConfig),
+	}
+}
+
+// Reset clears all per-run accumulator state. Callers that re-run
+// GitAnalyzeAll repeatedly on the same GitReviewer (e.g. the -http
+// dashboard's poll loop) must call this first, since GitAnalyzeAll only
+// ever appends/sets into these maps and never forgets a status flag once
+// a repository has had it.
+func (this *GitReviewer) Reset() {
+	this.erred = make(map[string]string)
+	this.messy = make(map[string]string)
+	this.ahead = make(map[string]string)
+	this.behind = make(map[string]string)
+	this.fetched = make(map[string]string)
+	this.journal = make(map[string]string)
+	this.omitted = make(map[string]string)
+	this.skipped = make(map[string]string)
+	this.timedOut = make(map[string]string)
+	this.branches = make(map[string]string)
+	this.remotes = make(map[string]string)
+	this.remoteHeads = make(map[string]string)
+	this.repoConfigs = make(map[string]RepoConfig)
+}
+
+// repoContext derives a per-repository context bounded by -timeout from
+// the reviewer's root context, so a single hung git command can't stall
+// the whole run.
+func (this *GitReviewer) repoContext() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(this.ctx, this.config.PerRepoTimeout)
+}
+
+// SyncNotesBeforeAnalysis fetches the shared refs/notes/gitreview ref from
+// origin (when -fetch-notes is set) so this run sees review state recorded
+// by other teammates before deciding what counts as already reviewed.
+func (this *GitReviewer) SyncNotesBeforeAnalysis() {
+	if !this.config.FetchNotes {
+		return
+	}
+	for _, path := range this.repoPaths {
+		ctx, cancel := this.repoContext()
+		this.ledger.Fetch(ctx, path)
+		cancel()
+	}
+}
+
+// SyncNotesAfterReview pushes the shared refs/notes/gitreview ref back to
+// origin (when -push-notes is set) so other teammates see what was just
+// reviewed.
+func (this *GitReviewer) SyncNotesAfterReview() {
+	if !this.config.PushNotes {
+		return
+	}
+	for path := range this.journal {
+		ctx, cancel := this.repoContext()
+		this.ledger.Push(ctx, path)
+		cancel()
+	}
+}
+
+// loadRepoConfigs reads the review.* git-config overrides for every
+// repository in this run, so the analyzer can compare against each
+// repository's own branch/remote instead of a hard-coded origin/master.
+func (this *GitReviewer) loadRepoConfigs() {
+	for _, path := range this.repoPaths {
+		ctx, cancel := this.repoContext()
+		this.repoConfigs[path] = loadRepoConfig(ctx, path)
+		cancel()
 	}
 }
 
 func (this *GitReviewer) GitAnalyzeAll() {
 	log.Printf("Analyzing %d git repositories...", len(this.repoPaths))
 	log.Println("Legend: [!] = error; [M] = messy; [A] = ahead; [B] = behind; [F] = fetched; [O] = omitted; [S] = skipped;")
-	reports := NewAnalyzer(workerCount).AnalyzeAll(this.repoPaths)
+	this.loadRepoConfigs()
+	reports := NewAnalyzer(workerCount).AnalyzeAll(this.ctx, this.config.PerRepoTimeout, this.repoPaths, this.repoConfigs)
 	for _, report := range reports {
 		if len(report.StatusError) > 0 {
 			this.erred[report.RepoPath] += report.StatusError
+			this.recordTimeout(report.RepoPath, report.StatusError)
 			log.Println(report.RepoPath, report.StatusError)
 		}
 		if len(report.FetchError) > 0 {
 			this.erred[report.RepoPath] += report.FetchError
+			this.recordTimeout(report.RepoPath, report.FetchError)
 			log.Println(report.RepoPath, report.FetchError)
 		}
 		if len(report.RevListError) > 0 {
 			this.erred[report.RepoPath] += report.RevListError
+			this.recordTimeout(report.RepoPath, report.RevListError)
 			log.Println(report.RepoPath, report.RevListError)
 		}
 
 		if len(report.StatusOutput) > 0 {
 			this.messy[report.RepoPath] += report.StatusOutput
 		}
-		if len(report.RevListAhead) > 0 {
-			this.ahead[report.RepoPath] += report.RevListAhead
-		}
-		if len(report.RevListBehind) > 0 {
-			this.behind[report.RepoPath] += report.RevListBehind
-		}
 		if len(report.SkipOutput) > 0 {
 			this.skipped[report.RepoPath] += report.SkipOutput
 		}
@@ -75,7 +160,25 @@ func (this *GitReviewer) GitAnalyzeAll() {
 			this.omitted[report.RepoPath] += report.OmitOutput
 		}
 
-		if this.config.GitFetch && len(report.FetchOutput) > 0 {
+		this.branches[report.RepoPath] = report.Branch
+		this.remotes[report.RepoPath] = report.RemoteOutput
+		this.remoteHeads[report.RepoPath] = report.RemoteHead
+
+		// Dedup against the fetched remote tip, not the local checkout's HEAD:
+		// a repo that's behind and never pulled/merged locally would otherwise
+		// look "already reviewed" forever after the first pass.
+		ledgerCtx, cancelLedgerCtx := this.repoContext()
+		alreadyReviewed := len(report.RemoteHead) > 0 && this.ledger.LastReviewed(ledgerCtx, report.RepoPath, report.RemoteHead) == report.RemoteHead
+		cancelLedgerCtx()
+
+		if len(report.RevListAhead) > 0 {
+			this.ahead[report.RepoPath] += report.RevListAhead
+		}
+		if len(report.RevListBehind) > 0 && !alreadyReviewed {
+			this.behind[report.RepoPath] += report.RevListBehind
+		}
+
+		if this.config.GitFetch && len(report.FetchOutput) > 0 && !alreadyReviewed {
 			this.fetched[report.RepoPath] += report.FetchOutput + report.RevListOutput
 
 			if this.canJournal(report) {
@@ -84,8 +187,19 @@ func (this *GitReviewer) GitAnalyzeAll() {
 		}
 	}
 }
+
+// recordTimeout buckets a repository under timedOut when its error looks
+// like a cancelled/expired context, so -timeout-induced failures are
+// distinguishable from ordinary git errors in the review summary.
+func (this *GitReviewer) recordTimeout(repoPath, errorText string) {
+	if strings.Contains(errorText, context.DeadlineExceeded.Error()) || strings.Contains(errorText, context.Canceled.Error()) {
+		this.timedOut[repoPath] += errorText
+	}
+}
+
 func (this *GitReviewer) canJournal(report *GitReport) bool {
-	if !strings.Contains(report.RemoteOutput, "smartystreets") { // Exclude externals from code review journal.
+	// Exclude externals from the code review journal, unless the repo opted in via review.include.
+	if !strings.Contains(report.RemoteOutput, "smartystreets") && !this.repoConfigs[report.RepoPath].Include {
 		return false
 	}
 	if _, found := this.omitted[report.RepoPath]; found {
@@ -127,29 +241,60 @@ func (this *GitReviewer) ReviewAll() {
 	printMapKeys(this.fetched, "Repositories with new content since the last review: %d")
 	printMapKeys(this.journal, "Repositories to be included in the final report: %d")
 	printMapKeys(this.skipped, "Repositories that were skipped: %d")
+	printMapKeys(this.timedOut, "Repositories that timed out: %d")
 	printStrings(reviewable, "Repositories to be reviewed: %d")
 
+	reviewable = this.editReviewQueue(reviewable)
+	if len(reviewable) == 0 {
+		log.Println("Review queue was emptied in the editor; nothing to review at this time.")
+		return
+	}
+
 	in := prompt(fmt.Sprintf("Press <ENTER> to initiate the review process (will open %d review windows), or 'q' to quit...", len(reviewable)))
 	if len(in) > 0 && in[0] == 'q' {
 		os.Exit(0)
 	}
 
 	for _, path := range reviewable {
-		log.Printf("Opening %s at %s", this.config.GitGUILauncher, path)
+		launcher := this.config.GitGUILauncher
+		if override := this.repoConfigs[path].GUI; len(override) > 0 {
+			launcher = override
+		}
+
+		log.Printf("Opening %s at %s", launcher, path)
 		var err error
-		if this.config.GitGUILauncher == "gitk" {
+		if launcher == "gitk" {
 			tmp, _ := os.Getwd()
 			os.Chdir(path)
-			err = exec.Command(this.config.GitGUILauncher, "--all").Run()
+			err = exec.CommandContext(this.ctx, launcher, "--all").Run()
 			os.Chdir(tmp)
 		} else {
-			err = exec.Command(this.config.GitGUILauncher, path).Run()
+			err = exec.CommandContext(this.ctx, launcher, path).Run()
 		}
 		if err != nil {
 			log.Println("Failed to open git GUI:", err)
 		}
 		time.Sleep(time.Millisecond * 250)
 	}
+
+	this.recordReviewed(reviewable)
+}
+
+// recordReviewed marks every repository that was actually surfaced in this
+// run's (possibly editor-curated) queue as reviewed, keyed on its fetched
+// remote tip. This covers repositories excluded from the final journal
+// (externals, review.omit) too, so they aren't re-flagged as reviewable on
+// every future run just because they never make the report.
+func (this *GitReviewer) recordReviewed(reviewable []string) {
+	for _, path := range reviewable {
+		head := this.remoteHeads[path]
+		if len(head) == 0 {
+			continue
+		}
+		ctx, cancel := this.repoContext()
+		this.ledger.Record(ctx, path, head, this.branches[path])
+		cancel()
+	}
 }
 
 //noinspection GoUnhandledErrorResult
@@ -163,13 +308,162 @@ func (this *GitReviewer) PrintCodeReviewLogEntry() {
 	writer := this.config.OpenOutputWriter()
 	defer func() { _ = writer.Close() }()
 
-	fmt.Fprintln(writer)
-	fmt.Fprintln(writer)
-	fmt.Fprintln(writer, "##", time.Now().Format("2006-01-02"))
-	fmt.Fprintln(writer)
-	for _, review := range this.journal {
-		fmt.Fprintln(writer, review)
+	reporter := NewReporter(this.config.Format)
+	if err := reporter.Report(writer, this.reportEntries()); err != nil {
+		log.Println("Could not write report:", err)
+	}
+}
+
+// reportEntries converts the journaled repositories into the
+// format-independent shape a Reporter renders.
+func (this *GitReviewer) reportEntries() []ReportEntry {
+	paths := make([]string, 0, len(this.journal))
+	for path := range this.journal {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	now := time.Now()
+	entries := make([]ReportEntry, 0, len(paths))
+	for _, path := range paths {
+		entries = append(entries, ReportEntry{
+			Path:           path,
+			Remote:         strings.TrimSpace(this.remotes[path]),
+			Branch:         this.branches[path],
+			Ahead:          countLines(this.ahead[path]),
+			Behind:         countLines(this.behind[path]),
+			MessyFiles:     nonEmptyLines(this.messy[path]),
+			FetchedCommits: nonEmptyLines(this.fetched[path]),
+			Errors:         nonEmptyLines(this.erred[path]),
+			Tags:           this.repoConfigs[path].Tags,
+			ReviewedAt:     now,
+		})
+	}
+	return entries
+}
+
+// editReviewQueue writes the reviewable repositories to a temp file, one
+// per line, and opens it in an editor so the queue can be curated before
+// any GUI windows launch. Commenting out or deleting a line drops that
+// repository from this run; reordering lines controls launch order.
+func (this *GitReviewer) editReviewQueue(reviewable []string) []string {
+	file, err := os.CreateTemp("", "gitreview-queue-*")
+	if err != nil {
+		log.Println("Could not create review queue file, skipping edit step:", err)
+		return reviewable
+	}
+	defer os.Remove(file.Name())
+
+	fmt.Fprintln(file, "# Edit this queue before the review begins.")
+	fmt.Fprintln(file, "# Delete or comment out a line to skip that repository.")
+	fmt.Fprintln(file, "# Reorder lines to control the order windows are opened in.")
+	for _, path := range reviewable {
+		fmt.Fprintln(file, this.queueLine(path))
+	}
+	if err = file.Close(); err != nil {
+		log.Println("Could not finish writing review queue file, skipping edit step:", err)
+		return reviewable
+	}
+
+	editor := this.resolveEditor(reviewable)
+	cmd := exec.CommandContext(this.ctx, editor, file.Name())
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err = cmd.Run(); err != nil {
+		log.Printf("Failed to launch editor %q for review queue, using original order: %s", editor, err)
+		return reviewable
+	}
+
+	edited, err := os.ReadFile(file.Name())
+	if err != nil {
+		log.Println("Could not read edited review queue, using original order:", err)
+		return reviewable
+	}
+
+	var curated []string
+	for _, line := range strings.Split(string(edited), "\n") {
+		line = strings.TrimSpace(line)
+		if len(line) == 0 || line[0] == '#' {
+			continue
+		}
+		curated = append(curated, strings.SplitN(line, "\t", 2)[0])
+	}
+	return curated
+}
+
+// queueLine renders the one-line summary shown for path in the editable
+// review queue: status flags, ahead/behind counts, and the first line of
+// its 'git status --porcelain' output (empty when it isn't messy).
+func (this *GitReviewer) queueLine(path string) string {
+	flags := ""
+	if _, found := this.erred[path]; found {
+		flags += "!"
+	}
+	if _, found := this.messy[path]; found {
+		flags += "M"
+	}
+	if _, found := this.ahead[path]; found {
+		flags += "A"
+	}
+	if _, found := this.behind[path]; found {
+		flags += "B"
+	}
+	if _, found := this.fetched[path]; found {
+		flags += "F"
+	}
+
+	counts := fmt.Sprintf("ahead=%d behind=%d", countLines(this.ahead[path]), countLines(this.behind[path]))
+	top := firstLine(this.messy[path])
+
+	return fmt.Sprintf("%s\t%-6s\t%s\t%s", path, "["+flags+"]", counts, top)
+}
+
+// resolveEditor picks the editor used for the review queue: the -editor
+// flag, then the 'review.editor' git-config value of the first queued
+// repository, then $EDITOR, then $VISUAL, then 'vi'.
+func (this *GitReviewer) resolveEditor(repoPaths []string) string {
+	if len(this.config.Editor) > 0 {
+		return this.config.Editor
+	}
+	if len(repoPaths) > 0 {
+		ctx, cancel := this.repoContext()
+		defer cancel()
+		out, err := exec.CommandContext(ctx, "git", "-C", repoPaths[0], "config", "--get", "review.editor").Output()
+		if err == nil {
+			if editor := strings.TrimSpace(string(out)); len(editor) > 0 {
+				return editor
+			}
+		}
+	}
+	if editor := os.Getenv("EDITOR"); len(editor) > 0 {
+		return editor
+	}
+	if editor := os.Getenv("VISUAL"); len(editor) > 0 {
+		return editor
+	}
+	return "vi"
+}
+
+func firstLine(s string) string {
+	return strings.SplitN(s, "\n", 2)[0]
+}
+
+func nonEmptyLines(s string) []string {
+	var lines []string
+	for _, line := range strings.Split(s, "\n") {
+		line = strings.TrimSpace(line)
+		if len(line) > 0 {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+func countLines(s string) int {
+	s = strings.TrimSpace(s)
+	if len(s) == 0 {
+		return 0
 	}
+	return len(strings.Split(s, "\n"))
 }
 
 const workerCount = 16
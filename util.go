@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// collectGitRepositories scans each root (non-recursively) for immediate
+// subdirectories that are git repositories.
+func collectGitRepositories(roots []string) []string {
+	var paths []string
+	for _, root := range roots {
+		root = strings.TrimSpace(root)
+		if len(root) == 0 {
+			continue
+		}
+
+		entries, err := os.ReadDir(root)
+		if err != nil {
+			continue
+		}
+
+		for _, entry := range entries {
+			if !entry.IsDir() {
+				continue
+			}
+			path := filepath.Join(root, entry.Name())
+			if isGitRepository(path) {
+				paths = append(paths, path)
+			}
+		}
+	}
+	return paths
+}
+
+// filterGitRepositories keeps only the paths that are actually git
+// repositories, so a bad entry in a -repo-list file or on the command
+// line doesn't crash the run.
+func filterGitRepositories(paths []string) []string {
+	var filtered []string
+	for _, path := range paths {
+		if len(path) == 0 {
+			continue
+		}
+		if isGitRepository(path) {
+			filtered = append(filtered, path)
+		} else {
+			log.Printf("Skipping non-repository path: %s", path)
+		}
+	}
+	return filtered
+}
+
+func isGitRepository(path string) bool {
+	_, err := os.Stat(filepath.Join(path, ".git"))
+	return err == nil
+}
+
+// sortUniqueKeys merges the keys of every map into a single sorted, deduped
+// slice.
+func sortUniqueKeys(maps ...map[string]string) []string {
+	seen := make(map[string]bool)
+	var keys []string
+	for _, m := range maps {
+		for key := range m {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func printMapKeys(m map[string]string, format string) {
+	if len(m) == 0 {
+		return
+	}
+	log.Printf(format, len(m))
+	for _, key := range sortUniqueKeys(m) {
+		log.Println(" -", key)
+	}
+}
+
+func printStrings(list []string, format string) {
+	if len(list) == 0 {
+		return
+	}
+	log.Printf(format, len(list))
+	for _, item := range list {
+		log.Println(" -", item)
+	}
+}
+
+// prompt writes message to stdout and reads back a single line of response
+// from stdin.
+//
+//noinspection GoUnhandledErrorResult
+func prompt(message string) string {
+	fmt.Print(message)
+	scanner := bufio.NewScanner(os.Stdin)
+	if scanner.Scan() {
+		return strings.TrimSpace(scanner.Text())
+	}
+	return ""
+}